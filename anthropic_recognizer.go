@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+var anthropicCircuitBreaker circuitBreaker
+
+// AnthropicRecognizer talks to the Claude Messages API's vision input.
+type AnthropicRecognizer struct {
+	apiKey string
+	model  string
+}
+
+func newAnthropicRecognizer() (*AnthropicRecognizer, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("server not configured: set ANTHROPIC_API_KEY env var")
+	}
+	model := os.Getenv("ANTHROPIC_MODEL")
+	if model == "" {
+		model = "claude-sonnet-4-5"
+	}
+	return &AnthropicRecognizer{apiKey: apiKey, model: model}, nil
+}
+
+func (a *AnthropicRecognizer) Recognize(ctx context.Context, image []byte, mime string, promptText string) (*CarResult, error) {
+	bodyObj := map[string]interface{}{
+		"model":      a.model,
+		"max_tokens": 1024,
+		"messages": []interface{}{
+			map[string]interface{}{
+				"role": "user",
+				"content": []interface{}{
+					map[string]interface{}{
+						"type": "image",
+						"source": map[string]interface{}{
+							"type":       "base64",
+							"media_type": mime,
+							"data":       base64.StdEncoding.EncodeToString(image),
+						},
+					},
+					map[string]interface{}{"type": "text", "text": promptText},
+				},
+			},
+		},
+	}
+
+	jb, err := json.Marshal(bodyObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	result, err := doWithRetry(ctx, client, &anthropicCircuitBreaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(jb))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("x-api-key", a.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request to Anthropic failed: %w", err)
+	}
+	respBytes := result.body
+
+	var apiResp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(respBytes, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	var rawText string
+	for _, c := range apiResp.Content {
+		if c.Type == "text" {
+			rawText = c.Text
+			break
+		}
+	}
+	if rawText == "" {
+		return nil, errors.New("no text content found in Anthropic response")
+	}
+
+	jsonStr, err := extractJSONFromText(rawText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract JSON from assistant text: %w", err)
+	}
+
+	var car CarResult
+	if err := json.Unmarshal([]byte(jsonStr), &car); err != nil {
+		return nil, fmt.Errorf("assistant text does not match car schema: %w", err)
+	}
+	if err := validateCarIdentification(&car); err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+func (a *AnthropicRecognizer) ModelTag() string {
+	return "anthropic:" + a.model
+}
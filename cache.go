@@ -0,0 +1,143 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// ResultCache stores a /recognize result keyed by sha256(image bytes +
+// prompt hash + model tag), so a re-submitted photo doesn't cost another
+// paid API call.
+type ResultCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte) error
+}
+
+const defaultCacheSize = 256
+
+// newResultCache picks the backend selected by CACHE_BACKEND (default
+// "memory"; "disk" persists under CACHE_DIR, default "./cache").
+func newResultCache() (ResultCache, error) {
+	backend := os.Getenv("CACHE_BACKEND")
+	if backend == "" {
+		backend = "memory"
+	}
+
+	switch backend {
+	case "memory":
+		size := defaultCacheSize
+		if v := os.Getenv("CACHE_SIZE"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				size = n
+			}
+		}
+		return newLRUCache(size), nil
+	case "disk":
+		dir := os.Getenv("CACHE_DIR")
+		if dir == "" {
+			dir = "./cache"
+		}
+		return newDiskCache(dir)
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q (want memory or disk)", backend)
+	}
+}
+
+// lruCache is a fixed-size in-memory LRU. Plain container/list + map, since
+// the standard library already covers this without pulling in a dependency.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (c *lruCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*lruEntry).value = value
+		return nil
+	}
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = el
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+	return nil
+}
+
+// diskCache stores each entry as ./<dir>/<key>.json.
+type diskCache struct {
+	dir string
+}
+
+func newDiskCache(dir string) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	return c.dir + "/" + key + ".json"
+}
+
+func (c *diskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *diskCache) Set(key string, value []byte) error {
+	return os.WriteFile(c.path(key), value, 0o644)
+}
+
+// cacheKey hashes the normalized image bytes together with the rendered
+// prompt text and the recognizer's model tag, so the cache is invalidated
+// automatically whenever either changes (including a prompt variant or
+// template field picking a different rendered prompt).
+func cacheKey(image []byte, promptText, modelTag string) string {
+	h := sha256.New()
+	h.Write(image)
+	promptSum := sha256.Sum256([]byte(promptText))
+	h.Write(promptSum[:])
+	h.Write([]byte(modelTag))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CarIdentification is the typed shape of a /recognize result. Field set and
+// types must stay in sync with schemas/car.json, which is what we actually
+// hand to the model as the Structured Outputs schema.
+type CarIdentification struct {
+	Make       string  `json:"make"`
+	Model      string  `json:"model"`
+	Year       *int    `json:"year"`
+	Color      string  `json:"color"`
+	Plate      *string `json:"plate"`
+	Confidence float64 `json:"confidence"`
+}
+
+// loadCarSchema reads the JSON Schema used for the Responses API
+// text.format.schema field.
+func loadCarSchema(path string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema in %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// validateCarIdentification checks the fields a strict schema can't express
+// on its own (e.g. "make must be non-empty", not just "make must be a string").
+func validateCarIdentification(c *CarIdentification) error {
+	if c.Make == "" {
+		return errors.New("missing required field: make")
+	}
+	if c.Model == "" {
+		return errors.New("missing required field: model")
+	}
+	if c.Color == "" {
+		return errors.New("missing required field: color")
+	}
+	return nil
+}
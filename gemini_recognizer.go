@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+var geminiCircuitBreaker circuitBreaker
+
+// GeminiRecognizer talks to Google's Generative Language API (generateContent).
+type GeminiRecognizer struct {
+	apiKey string
+	model  string
+}
+
+func newGeminiRecognizer() (*GeminiRecognizer, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("server not configured: set GEMINI_API_KEY env var")
+	}
+	model := os.Getenv("GEMINI_MODEL")
+	if model == "" {
+		model = "gemini-2.5-flash"
+	}
+	return &GeminiRecognizer{apiKey: apiKey, model: model}, nil
+}
+
+func (g *GeminiRecognizer) Recognize(ctx context.Context, image []byte, mime string, promptText string) (*CarResult, error) {
+	bodyObj := map[string]interface{}{
+		"contents": []interface{}{
+			map[string]interface{}{
+				"parts": []interface{}{
+					map[string]interface{}{"text": promptText},
+					map[string]interface{}{
+						"inline_data": map[string]interface{}{
+							"mime_type": mime,
+							"data":      base64.StdEncoding.EncodeToString(image),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	jb, err := json.Marshal(bodyObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
+		g.model, url.QueryEscape(g.apiKey))
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	result, err := doWithRetry(ctx, client, &geminiCircuitBreaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jb))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request to Gemini failed: %w", err)
+	}
+	respBytes := result.body
+
+	var apiResp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(respBytes, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini response: %w", err)
+	}
+
+	var rawText string
+	if len(apiResp.Candidates) > 0 && len(apiResp.Candidates[0].Content.Parts) > 0 {
+		rawText = apiResp.Candidates[0].Content.Parts[0].Text
+	}
+	if rawText == "" {
+		return nil, errors.New("no text part found in Gemini response")
+	}
+
+	jsonStr, err := extractJSONFromText(rawText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract JSON from assistant text: %w", err)
+	}
+
+	var car CarResult
+	if err := json.Unmarshal([]byte(jsonStr), &car); err != nil {
+		return nil, fmt.Errorf("assistant text does not match car schema: %w", err)
+	}
+	if err := validateCarIdentification(&car); err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+func (g *GeminiRecognizer) ModelTag() string {
+	return "gemini:" + g.model
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+var errCircuitOpen = errors.New("circuit breaker open: upstream has been failing consecutively")
+
+const (
+	circuitBreakerThreshold = 5
+	circuitBreakerCooldown  = 30 * time.Second
+	maxUpstreamAttempts     = 3
+)
+
+// circuitBreaker trips open after circuitBreakerThreshold consecutive
+// failures and stays open for circuitBreakerCooldown, so a flapping upstream
+// doesn't get hammered by every incoming request while it's down. Each
+// Recognizer backend keeps its own, since an outage in one provider
+// shouldn't block requests to another.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return time.Now().After(cb.openUntil)
+}
+
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.openUntil = time.Time{}
+}
+
+func (cb *circuitBreaker) recordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	if cb.failures >= circuitBreakerThreshold {
+		cb.openUntil = time.Now().Add(circuitBreakerCooldown)
+	}
+}
+
+// httpResult is the successful outcome of doWithRetry: a 2xx status code
+// with its body already drained.
+type httpResult struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfterDelay parses a Retry-After header (seconds or HTTP-date form),
+// returning 0 if absent or unparseable.
+func retryAfterDelay(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// doWithRetry runs a request built fresh by newReq (so each retry gets its
+// own unread body) through client, retrying 429/500/502/503/504 responses and network
+// errors with jittered exponential backoff up to maxUpstreamAttempts total
+// attempts. It honors Retry-After when the upstream sends one, and checks cb
+// before doing any work so a tripped breaker fails fast instead of queuing
+// behind a backoff sleep.
+func doWithRetry(ctx context.Context, client *http.Client, cb *circuitBreaker, newReq func() (*http.Request, error)) (*httpResult, error) {
+	if !cb.allow() {
+		return nil, errCircuitOpen
+	}
+
+	bo := backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(500*time.Millisecond),
+		backoff.WithMaxInterval(30*time.Second),
+	)
+	withRetries := backoff.WithContext(backoff.WithMaxRetries(bo, maxUpstreamAttempts-1), ctx)
+
+	result, err := backoff.RetryNotifyWithData(func() (*httpResult, error) {
+		req, err := newReq()
+		if err != nil {
+			return nil, backoff.Permanent(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if retryableStatus(resp.StatusCode) {
+			if wait := retryAfterDelay(resp.Header); wait > 0 {
+				if wait > circuitBreakerCooldown {
+					wait = circuitBreakerCooldown
+				}
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return nil, backoff.Permanent(ctx.Err())
+				}
+			}
+			return nil, fmt.Errorf("upstream returned %d: %s", resp.StatusCode, string(body))
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			// A hard failure (bad API key, malformed request, not-found, ...) —
+			// don't burn retries on it, and don't let it masquerade as a
+			// successful httpResult for the caller to decode as JSON.
+			return nil, backoff.Permanent(fmt.Errorf("upstream returned %d: %s", resp.StatusCode, string(body)))
+		}
+
+		return &httpResult{statusCode: resp.StatusCode, header: resp.Header, body: body}, nil
+	}, withRetries, func(err error, d time.Duration) {
+		log.Printf("upstream call failed, retrying in %s: %v", d, err)
+	})
+
+	if err != nil {
+		cb.recordFailure()
+		return nil, err
+	}
+	cb.recordSuccess()
+	return result, nil
+}
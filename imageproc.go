@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	defaultMaxImageDim = 1568
+	defaultJPEGQuality = 85
+)
+
+// imageNormalizer downscales and re-encodes uploaded photos before they're
+// sent to a vision model, so a 12MP phone photo doesn't turn into a huge,
+// slow, and expensive base64 payload.
+type imageNormalizer struct {
+	maxDim    int
+	quality   int
+	stripEXIF bool
+}
+
+func newImageNormalizer() *imageNormalizer {
+	n := &imageNormalizer{
+		maxDim:    defaultMaxImageDim,
+		quality:   defaultJPEGQuality,
+		stripEXIF: true,
+	}
+	if v := os.Getenv("MAX_IMAGE_DIM"); v != "" {
+		if d, err := strconv.Atoi(v); err == nil && d > 0 {
+			n.maxDim = d
+		}
+	}
+	if v := os.Getenv("JPEG_QUALITY"); v != "" {
+		if q, err := strconv.Atoi(v); err == nil && q > 0 && q <= 100 {
+			n.quality = q
+		}
+	}
+	if v := os.Getenv("STRIP_EXIF"); v != "" {
+		n.stripEXIF = v == "true"
+	}
+	return n
+}
+
+// normalize auto-orients, downscales to maxDim on the long edge, and
+// re-encodes as JPEG. Auto-orientation always runs as part of decode; the
+// only thing that can be skipped is the lossy re-encode, and only when the
+// caller explicitly doesn't care about stripping EXIF (stripEXIF false) and
+// the photo is already within maxDim — otherwise there'd be nothing gained
+// by skipping it (stripping EXIF, same as applying orientation, requires
+// writing the image back out).
+func (n *imageNormalizer) normalize(data []byte, mime string) ([]byte, string, error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := img.Bounds()
+	longEdge := bounds.Dx()
+	if bounds.Dy() > longEdge {
+		longEdge = bounds.Dy()
+	}
+
+	if longEdge <= n.maxDim && !n.stripEXIF {
+		return data, mime, nil
+	}
+
+	resized := img
+	if longEdge > n.maxDim {
+		resized = imaging.Fit(img, n.maxDim, n.maxDim, imaging.Lanczos)
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, resized, imaging.JPEG, imaging.JPEGQuality(n.quality)); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// JobStatus is the lifecycle state of an async /jobs recognition request.
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobError   JobStatus = "error"
+)
+
+// Job tracks one async recognition request end to end. It's what GET
+// /jobs/{id} serializes back to the client.
+type Job struct {
+	ID        string     `json:"job_id"`
+	Status    JobStatus  `json:"status"`
+	Result    *CarResult `json:"result,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+var errJobNotFound = errors.New("job not found")
+
+// errQueueEmpty means a JobQueue.Pop poll came back with nothing to do; it's
+// not a real failure, just a cue for the worker loop to poll again.
+var errQueueEmpty = errors.New("queue poll timed out, no job available")
+
+// JobStore persists job state so GET /jobs/{id} can be answered from any
+// worker, and (with the Redis backend) so jobs survive a server restart.
+type JobStore interface {
+	Save(job *Job) error
+	Get(id string) (*Job, error)
+	Delete(id string) error
+}
+
+// JobQueue hands job IDs off from the HTTP handler to the worker pool. Pop
+// blocks until a job is available or ctx is done; a backend that polls may
+// also return errQueueEmpty when a poll ends with no job.
+type JobQueue interface {
+	Push(ctx context.Context, jobID string) error
+	Pop(ctx context.Context) (string, error)
+}
+
+// validJobID matches the hex strings newJobID produces, so a path like
+// "../../etc/passwd" can't reach fileBlobStore's filepath.Join as an id.
+var validJobID = regexp.MustCompile(`^[0-9a-f]{8,128}$`)
+
+func isValidJobID(id string) bool {
+	return validJobID.MatchString(id)
+}
+
+func newJobID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand failing means the system RNG is broken; a timestamp
+		// collision is the least bad fallback over refusing the request.
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileBlobStore holds the uploaded photo for a job between submission and
+// the worker picking it up, and after that it's no longer needed since the
+// result is what GET /jobs/{id} serves.
+type fileBlobStore struct {
+	dir string
+}
+
+func newFileBlobStore(dir string) (*fileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileBlobStore{dir: dir}, nil
+}
+
+func (b *fileBlobStore) path(id string) string {
+	return filepath.Join(b.dir, id+".blob")
+}
+
+func (b *fileBlobStore) mimePath(id string) string {
+	return filepath.Join(b.dir, id+".mime")
+}
+
+func (b *fileBlobStore) promptPath(id string) string {
+	return filepath.Join(b.dir, id+".prompt")
+}
+
+func (b *fileBlobStore) Save(id, mime, promptText string, data []byte) error {
+	if err := os.WriteFile(b.path(id), data, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(b.mimePath(id), []byte(mime), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(b.promptPath(id), []byte(promptText), 0o644)
+}
+
+func (b *fileBlobStore) Load(id string) (data []byte, mime, promptText string, err error) {
+	data, err = os.ReadFile(b.path(id))
+	if err != nil {
+		return nil, "", "", err
+	}
+	mimeBytes, err := os.ReadFile(b.mimePath(id))
+	if err != nil {
+		return nil, "", "", err
+	}
+	promptBytes, err := os.ReadFile(b.promptPath(id))
+	if err != nil {
+		return nil, "", "", err
+	}
+	return data, string(mimeBytes), string(promptBytes), nil
+}
+
+func (b *fileBlobStore) Delete(id string) error {
+	os.Remove(b.path(id))
+	os.Remove(b.mimePath(id))
+	os.Remove(b.promptPath(id))
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultJobWorkers = 4
+
+// JobManager runs the worker pool that drains JobQueue and executes
+// recognitions in the background, so POST /jobs can return immediately
+// instead of holding the client's connection open for the upstream call.
+type JobManager struct {
+	store      JobStore
+	queue      JobQueue
+	blobs      *fileBlobStore
+	recognizer Recognizer
+	workers    int
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newJobManager(recognizer Recognizer) (*JobManager, error) {
+	store, queue, err := newJobBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	blobDir := os.Getenv("JOB_DATA_DIR")
+	if blobDir == "" {
+		blobDir = "./data/jobs"
+	}
+	blobs, err := newFileBlobStore(blobDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up job storage at %s: %w", blobDir, err)
+	}
+
+	workers := defaultJobWorkers
+	if v := os.Getenv("JOB_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			workers = n
+		}
+	}
+
+	return &JobManager{
+		store:      store,
+		queue:      queue,
+		blobs:      blobs,
+		recognizer: recognizer,
+		workers:    workers,
+		cancels:    make(map[string]context.CancelFunc),
+	}, nil
+}
+
+// newJobBackend picks the JobStore/JobQueue pair selected by JOB_BACKEND
+// (default "memory"; "redis" shares state across replicas via REDIS_ADDR).
+func newJobBackend() (JobStore, JobQueue, error) {
+	backend := os.Getenv("JOB_BACKEND")
+	if backend == "" {
+		backend = "memory"
+	}
+
+	switch backend {
+	case "memory":
+		return newMemoryJobStore(), newMemoryJobQueue(256), nil
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return newRedisJobStore(addr), newRedisJobQueue(addr), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown JOB_BACKEND %q (want memory or redis)", backend)
+	}
+}
+
+// Start launches the worker pool. It returns immediately; workers stop once
+// ctx is cancelled.
+func (jm *JobManager) Start(ctx context.Context) {
+	for i := 0; i < jm.workers; i++ {
+		go jm.worker(ctx)
+	}
+}
+
+func (jm *JobManager) worker(ctx context.Context) {
+	for {
+		id, err := jm.queue.Pop(ctx)
+		if err != nil {
+			if errors.Is(err, errQueueEmpty) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("job queue pop error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		jm.process(ctx, id)
+	}
+}
+
+func (jm *JobManager) process(parent context.Context, id string) {
+	job, err := jm.store.Get(id)
+	if err != nil {
+		log.Printf("job %s vanished before processing: %v", id, err)
+		return
+	}
+	if job.Status != JobQueued {
+		// Already cancelled (or, in principle, re-delivered) — nothing to do.
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(parent)
+	jm.mu.Lock()
+	jm.cancels[id] = cancel
+	jm.mu.Unlock()
+	defer func() {
+		jm.mu.Lock()
+		delete(jm.cancels, id)
+		jm.mu.Unlock()
+		cancel()
+	}()
+
+	job.Status = JobRunning
+	if err := jm.store.Save(job); err != nil {
+		log.Printf("job %s: failed to save running state: %v", id, err)
+	}
+
+	image, mime, promptText, err := jm.blobs.Load(id)
+	if err != nil {
+		job.Status = JobError
+		job.Error = fmt.Sprintf("failed to load stored photo: %v", err)
+		jm.store.Save(job)
+		jm.blobs.Delete(id)
+		return
+	}
+
+	result, err := jm.recognizer.Recognize(jobCtx, image, mime, promptText)
+	if err != nil {
+		job.Status = JobError
+		job.Error = err.Error()
+		jm.store.Save(job)
+		jm.blobs.Delete(id)
+		return
+	}
+
+	job.Status = JobDone
+	job.Result = result
+	jm.store.Save(job)
+	jm.blobs.Delete(id)
+}
+
+// submitQueuePushTimeout bounds how long Submit waits for room in the queue
+// before giving up, so a saturated queue fails a request with a clear error
+// instead of holding the handler goroutine open indefinitely.
+const submitQueuePushTimeout = 5 * time.Second
+
+var errQueueFull = errors.New("job queue is full, try again later")
+
+// Submit stores the photo, records a queued job, and enqueues it for a
+// worker to pick up. ctx is the inbound request's context, so a client that
+// disconnects stops waiting on a full queue too.
+func (jm *JobManager) Submit(ctx context.Context, image []byte, mime, promptText string) (*Job, error) {
+	id := newJobID()
+	if err := jm.blobs.Save(id, mime, promptText, image); err != nil {
+		return nil, fmt.Errorf("failed to store photo: %w", err)
+	}
+
+	job := &Job{ID: id, Status: JobQueued, CreatedAt: time.Now()}
+	if err := jm.store.Save(job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	pushCtx, cancel := context.WithTimeout(ctx, submitQueuePushTimeout)
+	defer cancel()
+	if err := jm.queue.Push(pushCtx, id); err != nil {
+		jm.blobs.Delete(id)
+		jm.store.Delete(id)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, errQueueFull
+		}
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return job, nil
+}
+
+func (jm *JobManager) Get(id string) (*Job, error) {
+	return jm.store.Get(id)
+}
+
+// Cancel stops a running job's in-flight upstream call (if any) and marks
+// the job as errored so a subsequent GET reflects the cancellation.
+func (jm *JobManager) Cancel(id string) error {
+	job, err := jm.store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	jm.mu.Lock()
+	cancel, running := jm.cancels[id]
+	jm.mu.Unlock()
+	if running {
+		cancel()
+	}
+
+	if job.Status == JobQueued || job.Status == JobRunning {
+		job.Status = JobError
+		job.Error = "cancelled by client"
+		if err := jm.store.Save(job); err != nil {
+			return err
+		}
+	}
+	jm.blobs.Delete(id)
+	return nil
+}
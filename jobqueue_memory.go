@@ -0,0 +1,30 @@
+package main
+
+import "context"
+
+// memoryJobQueue is the default JobQueue: an in-process buffered channel.
+type memoryJobQueue struct {
+	ch chan string
+}
+
+func newMemoryJobQueue(size int) *memoryJobQueue {
+	return &memoryJobQueue{ch: make(chan string, size)}
+}
+
+func (q *memoryJobQueue) Push(ctx context.Context, jobID string) error {
+	select {
+	case q.ch <- jobID:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *memoryJobQueue) Pop(ctx context.Context) (string, error) {
+	select {
+	case id := <-q.ch:
+		return id, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
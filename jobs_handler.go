@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// postJobsHandler accepts the same multipart "photo" field as /recognize
+// but enqueues the work and returns immediately instead of blocking on the
+// upstream call.
+func postJobsHandler(jm *JobManager, prompts *PromptRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 25<<20)
+		const maxMemory = 20 << 20
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			http.Error(w, "failed parse multipart form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		file, fh, err := r.FormFile("photo")
+		if err != nil {
+			http.Error(w, "missing form file 'photo': "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		imgBytes, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "failed read file: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		mimeType := http.DetectContentType(imgBytes)
+		if mimeType == "application/octet-stream" {
+			if t := fh.Header.Get("Content-Type"); t != "" {
+				mimeType = t
+			}
+		}
+
+		normalizer := newImageNormalizer()
+		imgBytes, mimeType, err = normalizer.normalize(imgBytes, mimeType)
+		if err != nil {
+			http.Error(w, "failed to process image: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		promptText, err := resolvePromptText(r, prompts)
+		if err != nil {
+			http.Error(w, "bad prompt variant: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		job, err := jm.Submit(r.Context(), imgBytes, mimeType, promptText)
+		if err != nil {
+			log.Printf("failed to submit job: %v", err)
+			status := http.StatusInternalServerError
+			if errors.Is(err, errQueueFull) {
+				status = http.StatusServiceUnavailable
+			}
+			http.Error(w, "failed to submit job: "+err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{
+			"job_id":     job.ID,
+			"status_url": "/jobs/" + job.ID,
+		})
+	}
+}
+
+// jobByIDHandler serves GET (poll status/result) and DELETE (cancel) for a
+// single job under /jobs/{id}.
+func jobByIDHandler(jm *JobManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		if id == "" {
+			http.Error(w, "missing job id", http.StatusBadRequest)
+			return
+		}
+		if !isValidJobID(id) {
+			http.Error(w, "invalid job id", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			job, err := jm.Get(id)
+			if err != nil {
+				if errors.Is(err, errJobNotFound) {
+					http.Error(w, "job not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "failed to read job: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(job)
+
+		case http.MethodDelete:
+			if err := jm.Cancel(id); err != nil {
+				if errors.Is(err, errJobNotFound) {
+					http.Error(w, "job not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "failed to cancel job: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "only GET/DELETE allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
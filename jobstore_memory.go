@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryJobStoreSweepInterval is how often newMemoryJobStore's background
+// reaper checks for jobs older than jobResultTTL, so a forgotten job (client
+// never polled, or errored and was never cleaned up) doesn't sit in memory
+// forever; the Redis backend gets the same bound from the EX on each SET.
+const memoryJobStoreSweepInterval = 10 * time.Minute
+
+// memoryJobStore is the default JobStore: fine for a single replica, lost on
+// restart. Entries older than jobResultTTL are reaped in the background so a
+// long-running process doesn't accumulate stale jobs without bound.
+type memoryJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	s := &memoryJobStore{jobs: make(map[string]*Job)}
+	go s.reapLoop()
+	return s
+}
+
+func (s *memoryJobStore) reapLoop() {
+	ticker := time.NewTicker(memoryJobStoreSweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		cutoff := time.Now().Add(-jobResultTTL)
+		s.mu.Lock()
+		for id, job := range s.jobs {
+			if job.CreatedAt.Before(cutoff) {
+				delete(s.jobs, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *memoryJobStore) Save(job *Job) error {
+	cp := *job
+	s.mu.Lock()
+	s.jobs[job.ID] = &cp
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryJobStore) Get(id string) (*Job, error) {
+	s.mu.RLock()
+	job, ok := s.jobs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, errJobNotFound
+	}
+	cp := *job
+	return &cp, nil
+}
+
+func (s *memoryJobStore) Delete(id string) error {
+	s.mu.Lock()
+	delete(s.jobs, id)
+	s.mu.Unlock()
+	return nil
+}
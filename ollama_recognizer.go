@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+var ollamaCircuitBreaker circuitBreaker
+
+// OllamaRecognizer talks to a local Ollama (or llama.cpp server with an
+// Ollama-compatible /api/generate endpoint) running a vision model.
+type OllamaRecognizer struct {
+	baseURL string
+	model   string
+}
+
+func newOllamaRecognizer() (*OllamaRecognizer, error) {
+	baseURL := os.Getenv("OLLAMA_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	model := os.Getenv("OLLAMA_MODEL")
+	if model == "" {
+		model = "llava"
+	}
+	return &OllamaRecognizer{baseURL: baseURL, model: model}, nil
+}
+
+func (o *OllamaRecognizer) Recognize(ctx context.Context, image []byte, mime string, promptText string) (*CarResult, error) {
+	bodyObj := map[string]interface{}{
+		"model":  o.model,
+		"prompt": promptText,
+		"images": []string{base64.StdEncoding.EncodeToString(image)},
+		"stream": false,
+	}
+
+	jb, err := json.Marshal(bodyObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	result, err := doWithRetry(ctx, client, &ollamaCircuitBreaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewReader(jb))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request to Ollama failed: %w", err)
+	}
+	respBytes := result.body
+
+	var apiResp struct {
+		Response string `json:"response"`
+	}
+	if err := json.Unmarshal(respBytes, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+	if apiResp.Response == "" {
+		return nil, errors.New("empty response field from Ollama")
+	}
+
+	jsonStr, err := extractJSONFromText(apiResp.Response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract JSON from assistant text: %w", err)
+	}
+
+	var car CarResult
+	if err := json.Unmarshal([]byte(jsonStr), &car); err != nil {
+		return nil, fmt.Errorf("assistant text does not match car schema: %w", err)
+	}
+	if err := validateCarIdentification(&car); err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+func (o *OllamaRecognizer) ModelTag() string {
+	return "ollama:" + o.model
+}
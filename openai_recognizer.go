@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// openAICircuitBreaker is shared by every OpenAIRecognizer instance so
+// repeated failures across requests (not just within one) trip the breaker.
+var openAICircuitBreaker circuitBreaker
+
+// OpenAIRecognizer talks to the OpenAI Responses API (api.openai.com/v1/responses).
+type OpenAIRecognizer struct {
+	apiKey string
+	model  string
+	// legacy skips Structured Outputs for models that don't support
+	// text.format=json_schema yet, falling back to extractCarJSON's
+	// brute-force scan of the assistant's free-form text.
+	legacy bool
+}
+
+func newOpenAIRecognizer() (*OpenAIRecognizer, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("server not configured: set OPENAI_API_KEY env var")
+	}
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		model = "gpt-5-mini"
+	}
+	return &OpenAIRecognizer{
+		apiKey: apiKey,
+		model:  model,
+		legacy: os.Getenv("LEGACY_MODEL") == "true",
+	}, nil
+}
+
+func (o *OpenAIRecognizer) Recognize(ctx context.Context, image []byte, mime string, promptText string) (*CarResult, error) {
+	b64 := base64.StdEncoding.EncodeToString(image)
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mime, b64)
+
+	bodyObj := map[string]interface{}{
+		"model": o.model,
+		"input": []interface{}{
+			map[string]interface{}{
+				"role": "user",
+				"content": []interface{}{
+					map[string]interface{}{"type": "input_text", "text": promptText},
+					map[string]interface{}{"type": "input_image", "image_url": dataURL},
+				},
+			},
+		},
+	}
+
+	if !o.legacy {
+		schema, err := loadCarSchema("schemas/car.json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load car schema: %w", err)
+		}
+		bodyObj["text"] = map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":   "json_schema",
+				"name":   "car",
+				"schema": schema,
+				"strict": true,
+			},
+		}
+	}
+
+	jb, err := json.Marshal(bodyObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	result, err := doWithRetry(ctx, client, &openAICircuitBreaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/responses", bytes.NewReader(jb))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request to OpenAI failed: %w", err)
+	}
+	respBytes := result.body
+
+	if o.legacy {
+		raw, err := extractCarJSON(respBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model output: %w", err)
+		}
+		var car CarResult
+		if err := json.Unmarshal(raw, &car); err != nil {
+			return nil, fmt.Errorf("model output does not match CarResult shape: %w", err)
+		}
+		return &car, nil
+	}
+
+	return decodeCarIdentification(respBytes)
+}
+
+// decodeCarIdentification decodes a Responses API payload straight into a
+// CarIdentification, trusting that text.format=json_schema made the model's
+// output text valid JSON matching schemas/car.json. No brute-force scanning:
+// with strict:true the model either returns exactly this shape or errors out
+// upstream, so a plain Unmarshal is all that's needed here.
+func decodeCarIdentification(respBytes []byte) (*CarIdentification, error) {
+	var apiResp struct {
+		Output []struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"output"`
+	}
+	if err := json.Unmarshal(respBytes, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAI response: %w", err)
+	}
+
+	var rawText string
+	for _, out := range apiResp.Output {
+		for _, c := range out.Content {
+			if strings.TrimSpace(c.Text) != "" {
+				rawText = c.Text
+				break
+			}
+		}
+		if rawText != "" {
+			break
+		}
+	}
+	if rawText == "" {
+		return nil, errors.New("no output content with text found in response (maybe only reasoning entries present)")
+	}
+
+	var car CarIdentification
+	if err := json.Unmarshal([]byte(rawText), &car); err != nil {
+		return nil, fmt.Errorf("assistant text does not match car schema: %w", err)
+	}
+	if err := validateCarIdentification(&car); err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+// tries to extract a JSON substring from arbitrary assistant text
+func extractJSONFromText(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	// 1) If whole text is valid JSON already — return it
+	var tmp interface{}
+	if json.Unmarshal([]byte(s), &tmp) == nil {
+		return s, nil
+	}
+
+	// 2) strip ```json ``` or ``` fences
+	reFence := regexp.MustCompile("(?s)```(?:json\\s*)?(.*?)```")
+	if m := reFence.FindStringSubmatch(s); len(m) >= 2 {
+		candidate := strings.TrimSpace(m[1])
+		if json.Unmarshal([]byte(candidate), &tmp) == nil {
+			return candidate, nil
+		}
+		// fallthrough and try substring search if fenced content isn't valid JSON
+	}
+
+	// 3) find first '{' or '[' and try to find a matching '}' or ']' by brute force attempts
+	start := strings.IndexAny(s, "{[")
+	if start == -1 {
+		return "", errors.New("no JSON object/array start found in text")
+	}
+
+	for end := len(s) - 1; end > start; end-- {
+		if (s[end] == '}' && s[start] == '{') || (s[end] == ']' && s[start] == '[') {
+			cand := strings.TrimSpace(s[start : end+1])
+			if json.Unmarshal([]byte(cand), &tmp) == nil {
+				return cand, nil
+			}
+		}
+	}
+
+	return "", errors.New("couldn't extract valid JSON substring from assistant text")
+}
+
+func extractCarJSON(respBytes []byte) ([]byte, error) {
+	// lightweight typed parse to reach content.text quickly
+	var apiResp struct {
+		Output []struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"output"`
+	}
+
+	if err := json.Unmarshal(respBytes, &apiResp); err != nil {
+		// если структура неожиданная, попробуем обойти через generic поиск "text"
+		var generic map[string]interface{}
+		if err2 := json.Unmarshal(respBytes, &generic); err2 != nil {
+			return nil, fmt.Errorf("failed to parse OpenAI response: %w", err)
+		}
+		// рекурсивный поиск первого поля "text"
+		var found string
+		var walk func(interface{})
+		walk = func(v interface{}) {
+			if found != "" {
+				return
+			}
+			switch vv := v.(type) {
+			case map[string]interface{}:
+				for k, val := range vv {
+					if k == "text" {
+						if s, ok := val.(string); ok && strings.TrimSpace(s) != "" {
+							found = s
+							return
+						}
+					}
+					walk(val)
+					if found != "" {
+						return
+					}
+				}
+			case []interface{}:
+				for _, item := range vv {
+					walk(item)
+					if found != "" {
+						return
+					}
+				}
+			}
+		}
+		walk(generic)
+		if found == "" {
+			return nil, errors.New("no output text found in response (generic parse)")
+		}
+		rawText := found
+		jsonStr, err := extractJSONFromText(rawText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract JSON from assistant text: %w", err)
+		}
+		var tmp interface{}
+		if err := json.Unmarshal([]byte(jsonStr), &tmp); err != nil {
+			return nil, fmt.Errorf("assistant text does not contain valid JSON: %w", err)
+		}
+		clean, _ := json.MarshalIndent(tmp, "", "  ")
+		return clean, nil
+	}
+
+	// проходимся по всем output -> content в поисках текста
+	var rawText string
+	for _, out := range apiResp.Output {
+		for _, c := range out.Content {
+			if strings.TrimSpace(c.Text) != "" {
+				rawText = c.Text
+				break
+			}
+		}
+		if rawText != "" {
+			break
+		}
+	}
+
+	if rawText == "" {
+		return nil, errors.New("no output content with text found in response (maybe only reasoning entries present)")
+	}
+
+	jsonStr, err := extractJSONFromText(rawText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract JSON from assistant text: %w", err)
+	}
+
+	var tmp interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &tmp); err != nil {
+		return nil, fmt.Errorf("assistant text does not contain valid JSON: %w", err)
+	}
+
+	clean, err := json.MarshalIndent(tmp, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reformat JSON: %w", err)
+	}
+
+	return clean, nil
+}
+
+func (o *OpenAIRecognizer) ModelTag() string {
+	return "openai:" + o.model
+}
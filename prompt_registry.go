@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultPromptVariant = "car_identification"
+
+// PromptTemplateData is what a prompt file can reference via Go template
+// syntax, e.g. "{{.Locale}}" or "{{if .RequireVIN}}...{{end}}".
+type PromptTemplateData struct {
+	Locale     string
+	RequireVIN bool
+}
+
+// PromptRegistry loads every .txt file under a directory as a named prompt
+// variant (the filename minus extension) and watches the directory so
+// edits take effect without a server restart.
+type PromptRegistry struct {
+	dir string
+
+	mu    sync.RWMutex
+	texts map[string]string
+}
+
+func newPromptRegistry(dir string) (*PromptRegistry, error) {
+	r := &PromptRegistry{dir: dir}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	if err := r.watch(); err != nil {
+		// Hot-reload is a nicety, not a requirement — keep serving the
+		// variants we already loaded if the watcher can't start (e.g. too
+		// many inotify watches on the host).
+		log.Printf("prompt hot-reload disabled: %v", err)
+	}
+	return r, nil
+}
+
+func (r *PromptRegistry) reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read prompts dir %s: %w", r.dir, err)
+	}
+
+	texts := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".txt" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read prompt %s: %w", e.Name(), err)
+		}
+		name := strings.TrimSuffix(e.Name(), ".txt")
+		texts[name] = string(data)
+	}
+
+	r.mu.Lock()
+	r.texts = texts
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *PromptRegistry) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(r.dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := r.reload(); err != nil {
+					log.Printf("failed to reload prompts after %s: %v", event, err)
+				} else {
+					log.Printf("reloaded prompt variants after %s", event)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("prompt watcher error: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Has reports whether name is a loaded variant. Callers must check this
+// before Render so unvalidated client input never reaches os.ReadFile/template
+// parsing paths beyond the allowlist of what's actually on disk.
+func (r *PromptRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.texts[name]
+	return ok
+}
+
+// Render returns the named variant with {{...}} fields substituted from data.
+func (r *PromptRegistry) Render(name string, data PromptTemplateData) (string, error) {
+	r.mu.RLock()
+	raw, ok := r.texts[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown prompt variant %q", name)
+	}
+
+	tmpl, err := template.New(name).Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("prompt variant %q is not a valid template: %w", name, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt variant %q: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// resolvePromptText picks the variant requested via the "prompt" query
+// param or the X-Prompt-Variant header (query param wins if both are set),
+// falling back to defaultPromptVariant, then renders it with any locale /
+// VIN requirements the caller passed in the same request.
+func resolvePromptText(r *http.Request, registry *PromptRegistry) (string, error) {
+	name := r.URL.Query().Get("prompt")
+	if name == "" {
+		name = r.Header.Get("X-Prompt-Variant")
+	}
+	if name == "" {
+		name = defaultPromptVariant
+	}
+	if !registry.Has(name) {
+		return "", fmt.Errorf("unknown prompt variant %q", name)
+	}
+
+	data := PromptTemplateData{
+		Locale:     r.URL.Query().Get("locale"),
+		RequireVIN: r.URL.Query().Get("require_vin") == "true",
+	}
+	return registry.Render(name, data)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CarResult is what every Recognizer backend returns, regardless of which
+// vision API produced it. It's the same shape the OpenAI Structured Outputs
+// schema describes, so we reuse CarIdentification rather than duplicate it.
+type CarResult = CarIdentification
+
+// Recognizer identifies a car from a photo. Implementations own their own
+// request shape, auth, and response parsing for whichever vision API they
+// talk to.
+type Recognizer interface {
+	Recognize(ctx context.Context, image []byte, mime string, promptText string) (*CarResult, error)
+	// ModelTag identifies the backend and model in use, e.g. "openai:gpt-5-mini".
+	// Used as part of the result cache key so switching models invalidates it.
+	ModelTag() string
+}
+
+// newRecognizer builds the Recognizer selected by the RECOGNIZER env var
+// (default "openai"). It reads whatever env vars that backend needs itself,
+// so callers don't need to know which backend they got.
+func newRecognizer() (Recognizer, error) {
+	backend := os.Getenv("RECOGNIZER")
+	if backend == "" {
+		backend = "openai"
+	}
+
+	switch backend {
+	case "openai":
+		return newOpenAIRecognizer()
+	case "anthropic":
+		return newAnthropicRecognizer()
+	case "gemini":
+		return newGeminiRecognizer()
+	case "ollama":
+		return newOllamaRecognizer()
+	default:
+		return nil, fmt.Errorf("unknown RECOGNIZER backend %q (want openai, anthropic, gemini or ollama)", backend)
+	}
+}
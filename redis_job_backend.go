@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// jobResultTTL bounds how long a finished job's state sticks around in
+// Redis before it's reclaimed; clients are expected to poll shortly after
+// submitting.
+const jobResultTTL = 24 * time.Hour
+
+func newRedisPool(addr string) *redis.Pool {
+	return &redis.Pool{
+		Dial:        func() (redis.Conn, error) { return redis.Dial("tcp", addr) },
+		MaxIdle:     8,
+		IdleTimeout: 5 * time.Minute,
+	}
+}
+
+// redisJobStore persists job state in Redis so jobs survive a server
+// restart and can be read back by any replica.
+type redisJobStore struct {
+	pool *redis.Pool
+}
+
+func newRedisJobStore(addr string) *redisJobStore {
+	return &redisJobStore{pool: newRedisPool(addr)}
+}
+
+func (s *redisJobStore) Save(job *Job) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("SET", "job:"+job.ID, raw, "EX", int(jobResultTTL.Seconds()))
+	return err
+}
+
+func (s *redisJobStore) Get(id string) (*Job, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	raw, err := redis.Bytes(conn.Do("GET", "job:"+id))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *redisJobStore) Delete(id string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("DEL", "job:"+id)
+	return err
+}
+
+// redisJobQueue is a JobQueue backed by a Redis list, so multiple replicas
+// can share one queue instead of each draining its own in-memory channel.
+type redisJobQueue struct {
+	pool *redis.Pool
+	key  string
+}
+
+func newRedisJobQueue(addr string) *redisJobQueue {
+	return &redisJobQueue{pool: newRedisPool(addr), key: "jobs:queue"}
+}
+
+func (q *redisJobQueue) Push(ctx context.Context, jobID string) error {
+	conn := q.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("LPUSH", q.key, jobID)
+	return err
+}
+
+// pollInterval is how long each BRPOP waits before we check ctx and retry;
+// it keeps worker shutdown responsive without busy-polling Redis.
+const pollInterval = 5
+
+func (q *redisJobQueue) Pop(ctx context.Context) (string, error) {
+	conn := q.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.Strings(conn.Do("BRPOP", q.key, pollInterval))
+	if errors.Is(err, redis.ErrNil) {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", errQueueEmpty
+	}
+	if err != nil {
+		return "", err
+	}
+	return reply[1], nil
+}